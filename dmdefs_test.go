@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func testDMDEData() *DMDEData {
+	return &DMDEData{
+		Directories: []string{`Users\bob\Documents\`, `Users\bob\Documents\Old\`},
+		Files: []FileEntry{
+			{Path: `Users\bob\Documents\a.txt`, Size: 5},
+			{Path: `Users\bob\Documents\Old\b.txt`, Size: 6},
+		},
+	}
+}
+
+func TestNewDMDEFSLookup(t *testing.T) {
+	fsys, err := NewDMDEFS(testDMDEData())
+	if err != nil {
+		t.Fatalf("NewDMDEFS: %v", err)
+	}
+
+	info, err := fsys.Stat("Users/bob/Documents/a.txt")
+	if err != nil {
+		t.Fatalf("Stat a.txt: %v", err)
+	}
+	if info.Size() != 5 {
+		t.Errorf("a.txt size = %d, want 5", info.Size())
+	}
+	if info.IsDir() {
+		t.Errorf("a.txt reported as a directory")
+	}
+
+	// Lookups are case-insensitive, matching Windows path semantics.
+	if _, err := fsys.Stat("users/BOB/documents"); err != nil {
+		t.Errorf("case-insensitive Stat failed: %v", err)
+	}
+
+	entries, err := fsys.ReadDir("Users/bob/Documents")
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ReadDir returned %d entries, want 2 (a.txt, Old)", len(entries))
+	}
+}
+
+func TestCollectDMDETree(t *testing.T) {
+	fsys, err := NewDMDEFS(testDMDEData())
+	if err != nil {
+		t.Fatalf("NewDMDEFS: %v", err)
+	}
+	entries, err := collectDMDETree(fsys)
+	if err != nil {
+		t.Fatalf("collectDMDETree: %v", err)
+	}
+
+	// Users and Users/bob are synthesized intermediate directories on
+	// top of the 2 listed directories and 2 listed files.
+	if len(entries) != 6 {
+		t.Fatalf("collectDMDETree returned %d entries, want 6: %+v", len(entries), entries)
+	}
+
+	var foundFile, foundDir bool
+	for _, e := range entries {
+		switch e.path {
+		case "Users/bob/Documents/a.txt":
+			foundFile = true
+			if e.isDir || e.size != 5 {
+				t.Errorf("a.txt entry wrong: %+v", e)
+			}
+		case "Users":
+			foundDir = true
+			if !e.isDir {
+				t.Errorf("Users entry should be a directory: %+v", e)
+			}
+		}
+	}
+	if !foundFile || !foundDir {
+		t.Errorf("expected entries missing: foundFile=%v foundDir=%v", foundFile, foundDir)
+	}
+}
+
+func TestDMDEFSOpenMissing(t *testing.T) {
+	fsys, err := NewDMDEFS(testDMDEData())
+	if err != nil {
+		t.Fatalf("NewDMDEFS: %v", err)
+	}
+	if _, err := fsys.Open("does/not/exist"); err == nil {
+		t.Fatal("expected an error opening a missing path")
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
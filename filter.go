@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// filterRule is a single gitignore-style line: a glob pattern plus
+// whether it negates (re-includes) a path an earlier rule excluded.
+type filterRule struct {
+	pattern string
+	negate  bool
+}
+
+// SelectFilter decides whether a DMDE-relative path should be processed
+// by create/verify, modeled on restic's pipe.SelectFunc: a predicate
+// over a path (and whether it's a directory), built up from --include
+// globs, --exclude globs and gitignore-style filter files.
+type SelectFilter struct {
+	includes []string
+	rules    []filterRule
+}
+
+// Active reports whether the filter can actually exclude or restrict
+// anything, so callers can skip filter-aware bookkeeping (like count
+// validation) when the user passed no filtering flags at all.
+func (f *SelectFilter) Active() bool {
+	return f != nil && (len(f.includes) > 0 || len(f.rules) > 0)
+}
+
+// Allows reports whether relPath (DMDE-relative, forward-slash) should
+// be processed: it must match at least one --include pattern (if any
+// were given), and the last matching exclude/negate rule must not be a
+// plain exclude. As with gitignore, a pattern matching a directory
+// applies to everything under it, so matching also walks relPath's
+// ancestors, not just relPath itself.
+func (f *SelectFilter) Allows(relPath string, isDir bool) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.includes) > 0 {
+		included := false
+		for _, pattern := range f.includes {
+			if pathMatchesWithAncestors(pattern, relPath, isDir) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	excluded := false
+	for _, r := range f.rules {
+		if pathMatchesWithAncestors(r.pattern, relPath, isDir) {
+			excluded = !r.negate
+		}
+	}
+	return !excluded
+}
+
+// pathMatchesWithAncestors reports whether pattern matches relPath
+// itself, or matches one of relPath's ancestor directories. The latter
+// is what gives a directory pattern (e.g. "Users/bob/Documents/")
+// gitignore-style subtree semantics: excluding (or including) a
+// directory excludes (includes) everything under it, not just the
+// directory entry with that exact path.
+func pathMatchesWithAncestors(pattern, relPath string, isDir bool) bool {
+	if matchFilterPattern(pattern, relPath, isDir) {
+		return true
+	}
+	segs := strings.Split(relPath, "/")
+	for i := 1; i < len(segs); i++ {
+		if matchFilterPattern(pattern, strings.Join(segs[:i], "/"), true) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFilterPattern matches a single gitignore-style pattern against a
+// DMDE-relative path. A pattern containing "/" matches the full
+// relative path, with "**" recursively matching zero or more whole
+// segments (see glob.go's matchGlobSegments - the same recursive-glob
+// semantics documented for --glob apply here); a bare pattern matches
+// against any single path segment (the usual gitignore shorthand for
+// "this name anywhere in the tree"). A trailing "/" restricts the
+// pattern to directories.
+func matchFilterPattern(pattern, relPath string, isDir bool) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if dirOnly && !isDir {
+		return false
+	}
+	if pattern == "" {
+		return false
+	}
+
+	if strings.Contains(pattern, "/") {
+		return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+	}
+
+	for _, seg := range strings.Split(relPath, "/") {
+		if ok, _ := path.Match(pattern, seg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldPruneExtra decides, while walking the *real* target tree for
+// extras, whether relPath is fully excluded and its subtree can be
+// pruned outright. Allows already walks relPath's ancestors
+// (pathMatchesWithAncestors), so once it says no, every descendant of
+// relPath would say no too - there's no deeper path under an excluded
+// directory that Allows would let back in, so pruning here is always
+// safe (mirroring git's own rule that a pattern can't re-include
+// something under an already-excluded directory).
+func shouldPruneExtra(filter *SelectFilter, relPath string, isDir bool) bool {
+	return filter != nil && !filter.Allows(relPath, isDir)
+}
+
+// loadFilterFile reads a gitignore-style filter file: blank lines and
+// "#" comments are skipped, a line starting with "!" negates the
+// pattern that follows, and a "#include <path>" line pulls in another
+// filter file (resolved relative to the file containing it), mirroring
+// syncthing's .stignore #include directive. visited guards against
+// include cycles.
+func loadFilterFile(filterPath string, visited map[string]bool) ([]filterRule, error) {
+	abs, err := filepath.Abs(filterPath)
+	if err != nil {
+		return nil, err
+	}
+	if visited[abs] {
+		return nil, nil
+	}
+	visited[abs] = true
+
+	data, err := os.ReadFile(filterPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter file %q: %w", filterPath, err)
+	}
+
+	var rules []filterRule
+	for _, rawLine := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(strings.TrimRight(rawLine, "\r"))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#include ") {
+			includePath := strings.TrimSpace(strings.TrimPrefix(line, "#include "))
+			if !filepath.IsAbs(includePath) {
+				includePath = filepath.Join(filepath.Dir(filterPath), includePath)
+			}
+			included, err := loadFilterFile(includePath, visited)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, included...)
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(line, "!")
+		pattern := strings.TrimPrefix(line, "!")
+		rules = append(rules, filterRule{pattern: pattern, negate: negate})
+	}
+	return rules, nil
+}
+
+// buildSelectFilter assembles a SelectFilter from the parsed CLI
+// options. Filter-file rules are loaded first so that explicit
+// --exclude flags always take precedence over them.
+func buildSelectFilter(opts *cliOptions) (*SelectFilter, error) {
+	filter := &SelectFilter{includes: opts.includes}
+
+	if opts.filterFile != "" {
+		rules, err := loadFilterFile(opts.filterFile, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		filter.rules = append(filter.rules, rules...)
+	}
+	for _, pattern := range opts.excludes {
+		filter.rules = append(filter.rules, filterRule{pattern: pattern})
+	}
+
+	return filter, nil
+}
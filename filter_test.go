@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+func TestMatchFilterPatternRecursiveDoubleStar(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"Users/*/AppData/**", "Users/bob/AppData", true, true},
+		{"Users/*/AppData/**", "Users/bob/AppData/bar.txt", false, true},
+		{"Users/*/AppData/**", "Users/bob/AppData/Local/foo.txt", false, true},
+		{"Users/*/AppData/**", "Users/bob/Documents", true, false},
+		{"Users/*/AppData/**", "Users", true, false},
+	}
+	for _, c := range cases {
+		if got := matchFilterPattern(c.pattern, c.path, c.isDir); got != c.want {
+			t.Errorf("matchFilterPattern(%q, %q, %v) = %v, want %v", c.pattern, c.path, c.isDir, got, c.want)
+		}
+	}
+}
+
+func TestMatchFilterPatternDirOnly(t *testing.T) {
+	if matchFilterPattern("Documents/", "Users/Documents", false) {
+		t.Error("a trailing-slash pattern should not match a non-directory entry")
+	}
+	if !matchFilterPattern("Documents/", "Users/Documents", true) {
+		t.Error("bare pattern should match this name at any depth when isDir is true")
+	}
+}
+
+func TestSelectFilterAllowsSubtree(t *testing.T) {
+	filter := &SelectFilter{rules: []filterRule{{pattern: "Users/*/AppData/**"}}}
+
+	if filter.Allows("Users/bob/AppData", true) {
+		t.Error("AppData directory itself should be excluded")
+	}
+	if filter.Allows("Users/bob/AppData/Local/foo.txt", false) {
+		t.Error("a file two levels under the excluded AppData tree should be excluded")
+	}
+	if !filter.Allows("Users/bob/Documents", true) {
+		t.Error("an unrelated sibling directory should still be allowed")
+	}
+}
+
+func TestSelectFilterIncludeRestrictsToMatches(t *testing.T) {
+	filter := &SelectFilter{includes: []string{"Users/bob/Documents/"}}
+
+	if !filter.Allows("Users/bob/Documents/a.txt", false) {
+		t.Error("file under an included directory should be allowed")
+	}
+	if filter.Allows("Users/bob/Photos/x.jpg", false) {
+		t.Error("file outside every include pattern should not be allowed")
+	}
+}
+
+func TestSelectFilterNegationReincludesExactMatch(t *testing.T) {
+	filter := &SelectFilter{rules: []filterRule{
+		{pattern: "*.tmp"},
+		{pattern: "keep.tmp", negate: true},
+	}}
+
+	if filter.Allows("build.tmp", false) {
+		t.Error("build.tmp should be excluded by the *.tmp rule")
+	}
+	if !filter.Allows("keep.tmp", false) {
+		t.Error("keep.tmp should be re-included by the negating rule")
+	}
+}
+
+func TestShouldPruneExtraMirrorsAllows(t *testing.T) {
+	filter := &SelectFilter{rules: []filterRule{{pattern: "Users/*/AppData/**"}}}
+
+	if !shouldPruneExtra(filter, "Users/bob/AppData", true) {
+		t.Error("an excluded directory should be pruned")
+	}
+	if shouldPruneExtra(filter, "Users/bob/Documents", true) {
+		t.Error("an allowed directory should not be pruned")
+	}
+	if shouldPruneExtra(nil, "anything", true) {
+		t.Error("a nil filter should never prune")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// matchGlob matches a DMDE-relative forward-slash path against pattern
+// using path.Match semantics per path segment, extended with "**": unlike
+// a plain "*", "**" may consume zero or more whole segments (so
+// "Users/**" matches "Users", "Users/bob" and "Users/bob/Documents/x").
+func matchGlob(pattern, relPath string) bool {
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+}
+
+func matchGlobSegments(patternSegs, pathSegs []string) bool {
+	if len(patternSegs) == 0 {
+		return len(pathSegs) == 0
+	}
+	if patternSegs[0] == "**" {
+		if matchGlobSegments(patternSegs[1:], pathSegs) {
+			return true
+		}
+		if len(pathSegs) == 0 {
+			return false
+		}
+		return matchGlobSegments(patternSegs, pathSegs[1:])
+	}
+	if len(pathSegs) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(patternSegs[0], pathSegs[0]); !ok {
+		return false
+	}
+	return matchGlobSegments(patternSegs[1:], pathSegs[1:])
+}
+
+// globMayMatchPrefix reports whether some path extending relPath (a
+// partial path reached so far while walking) could still satisfy
+// pattern, so a walker can decide whether to keep descending into
+// relPath or prune it outright.
+func globMayMatchPrefix(pattern, relPath string) bool {
+	if relPath == "" || relPath == "." {
+		return true
+	}
+	return globSegsMayMatchPrefix(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+}
+
+func globSegsMayMatchPrefix(patternSegs, pathSegs []string) bool {
+	if len(pathSegs) == 0 {
+		return true
+	}
+	if len(patternSegs) == 0 {
+		return false
+	}
+	if patternSegs[0] == "**" {
+		return true
+	}
+	if ok, _ := path.Match(patternSegs[0], pathSegs[0]); !ok {
+		return false
+	}
+	return globSegsMayMatchPrefix(patternSegs[1:], pathSegs[1:])
+}
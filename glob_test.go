@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"**/*.psd", "Users/bob/a.psd", true},
+		{"**/*.psd", "a.psd", true},
+		{"**/*.psd", "a.txt", false},
+		{"Users/**", "Users", true},
+		{"Users/**", "Users/bob", true},
+		{"Users/**", "Users/bob/Documents/x", true},
+		{"Users/**", "Program Files", false},
+		{"Users/*/Documents", "Users/bob/Documents", true},
+		{"Users/*/Documents", "Users/bob/Pictures", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.path); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestGlobMayMatchPrefix(t *testing.T) {
+	if !globMayMatchPrefix("Users/**", "Users") {
+		t.Error("a partial path matching the fixed prefix should not be ruled out")
+	}
+	if globMayMatchPrefix("Users/**", "Program Files") {
+		t.Error("a fixed first segment should rule out a non-matching root")
+	}
+	if !globMayMatchPrefix("**/*.psd", "Program Files") {
+		t.Error("a leading ** can never be ruled out by a prefix")
+	}
+}
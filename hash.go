@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// newHasher builds a hash.Hash for the named algorithm. Only sha256 is
+// available: it's the only content-hash algorithm in the standard
+// library, and this tree has no go.mod to pull in a blake2b or xxh64
+// implementation.
+func newHasher(algo string) (hash.Hash, error) {
+	switch algo {
+	case "", "sha256":
+		return sha256.New(), nil
+	case "blake2b", "xxh64":
+		return nil, fmt.Errorf("--hash %s requires a dependency this build doesn't vendor; only sha256 is available", algo)
+	default:
+		return nil, fmt.Errorf("unknown hash algorithm %q (supported: sha256)", algo)
+	}
+}
+
+// hashAlgoLabel returns algo, or the name of the default algorithm if
+// algo is empty, purely for log output.
+func hashAlgoLabel(algo string) string {
+	if algo == "" {
+		return "sha256"
+	}
+	return algo
+}
+
+// hashFile streams name (as seen through fsys) into a fresh hasher for
+// algo and returns the lower-case hex digest.
+func hashFile(fsys fs.FS, name string, algo string) (string, error) {
+	h, err := newHasher(algo)
+	if err != nil {
+		return "", err
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// loadHashSidecar reads the sidecar next to listingPath (listingPath +
+// ".sha256"), keyed by DMDE-relative path lower-cased to match
+// dmdeNode's case-insensitive lookup. It understands two line formats:
+// the standard sha256sum "<hex>  <path>" / "<hex> *<path>", and this
+// tool's own --emit-manifest "<path><TAB><size><TAB><hex>". A missing
+// sidecar isn't an error - hashes are optional - but an unparseable line
+// in one that does exist is, so a malformed sidecar fails loudly instead
+// of silently verifying nothing.
+func loadHashSidecar(listingPath string) (map[string]string, error) {
+	sidecarPath := listingPath + ".sha256"
+	f, err := os.Open(sidecarPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read hash sidecar %q: %w", sidecarPath, err)
+	}
+	defer f.Close()
+
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		rawPath, digest, err := parseHashSidecarLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("hash sidecar %q: %w", sidecarPath, err)
+		}
+		rel, err := dmdeRelPath(rawPath)
+		if err != nil {
+			return nil, fmt.Errorf("hash sidecar %q: %w", sidecarPath, err)
+		}
+		hashes[strings.ToLower(rel)] = strings.ToLower(digest)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading hash sidecar %q: %w", sidecarPath, err)
+	}
+	return hashes, nil
+}
+
+// parseHashSidecarLine parses a single non-blank sidecar line in either
+// the sha256sum format or --emit-manifest's own tab-separated format,
+// returning the raw (un-normalized) path and hex digest it names. The
+// sha256sum format is checked first, since its leading 64-hex-digit
+// signature is unambiguous - unlike just checking for a tab, which a
+// sha256sum line can also contain if the path itself has one (DMDE
+// recoveries can carry corrupted/arbitrary filenames).
+func parseHashSidecarLine(line string) (rawPath, digest string, err error) {
+	if len(line) >= 64+1 && isHexDigest(line[:64]) && (line[64] == ' ' || line[64] == '\t') {
+		rest := strings.TrimLeft(line[64:], " \t*")
+		if rest == "" {
+			return "", "", fmt.Errorf("malformed sha256sum line %q: missing path", line)
+		}
+		return rest, line[:64], nil
+	}
+	if strings.Contains(line, "\t") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || !isHexDigest(fields[2]) {
+			return "", "", fmt.Errorf("malformed manifest line %q (want path<TAB>size<TAB>hash)", line)
+		}
+		return fields[0], fields[2], nil
+	}
+	return "", "", fmt.Errorf("malformed hash sidecar line %q (want sha256sum's \"<hex> <path>\" or manifest's \"path<TAB>size<TAB>hash\")", line)
+}
+
+func isHexDigest(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// emitManifest walks the files actually present under outputDir and
+// writes a "path<TAB>size<TAB>hash" sidecar to manifestPath, so a later
+// verify run (possibly against a different copy of the same recovery)
+// can catch bit-rot rather than just missing/wrong-size files.
+func emitManifest(outputDir, manifestPath, hashAlgo string) (int, error) {
+	actualFS := os.DirFS(outputDir)
+
+	type manifestLine struct {
+		path string
+		size int64
+		hash string
+	}
+	var lines []manifestLine
+	err := fs.WalkDir(actualFS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		digest, err := hashFile(actualFS, p, hashAlgo)
+		if err != nil {
+			return fmt.Errorf("hashing %q: %w", p, err)
+		}
+		lines = append(lines, manifestLine{path: p, size: info.Size(), hash: digest})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].path < lines[j].path })
+
+	out, err := os.Create(manifestPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create manifest %q: %w", manifestPath, err)
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	for _, l := range lines {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", filepath.ToSlash(l.path), l.size, l.hash)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write manifest %q: %w", manifestPath, err)
+	}
+	return len(lines), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestParseHashSidecarLineFormats(t *testing.T) {
+	const digest = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	cases := []struct {
+		name       string
+		line       string
+		wantPath   string
+		wantDigest string
+		wantErr    bool
+	}{
+		{"sha256sum text mode", digest + "  Users/bob/a.txt", "Users/bob/a.txt", digest, false},
+		{"sha256sum binary mode", digest + " *Users/bob/a.txt", "Users/bob/a.txt", digest, false},
+		{"manifest format", "Users/bob/a.txt\t5\t" + digest, "Users/bob/a.txt", digest, false},
+		{"manifest-shaped path with a tab still wins by signature", digest + "\tUsers/bob/a.txt", "Users/bob/a.txt", digest, false},
+		{"malformed manifest, wrong field count", "Users/bob/a.txt\t5", "", "", true},
+		{"not a recognized format", "not a hash line at all", "", "", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gotPath, gotDigest, err := parseHashSidecarLine(c.line)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("parseHashSidecarLine(%q): expected an error, got none", c.line)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHashSidecarLine(%q): unexpected error: %v", c.line, err)
+			}
+			if gotPath != c.wantPath || gotDigest != c.wantDigest {
+				t.Errorf("parseHashSidecarLine(%q) = (%q, %q), want (%q, %q)", c.line, gotPath, gotDigest, c.wantPath, c.wantDigest)
+			}
+		})
+	}
+}
+
+func TestIsHexDigest(t *testing.T) {
+	if !isHexDigest("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855") {
+		t.Error("expected a valid 64-char hex digest to pass")
+	}
+	if isHexDigest("too short") {
+		t.Error("expected a short string to fail")
+	}
+	if isHexDigest("g3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855") {
+		t.Error("expected a non-hex character to fail")
+	}
+}
+
+func TestNewHasher(t *testing.T) {
+	if _, err := newHasher(""); err != nil {
+		t.Errorf("newHasher(\"\") should default to sha256, got error: %v", err)
+	}
+	if _, err := newHasher("sha256"); err != nil {
+		t.Errorf("newHasher(\"sha256\") should succeed, got error: %v", err)
+	}
+	if _, err := newHasher("blake2b"); err == nil {
+		t.Error("newHasher(\"blake2b\") should fail in this build (no vendored dependency)")
+	}
+	if _, err := newHasher("made-up-algo"); err == nil {
+		t.Error("newHasher with an unknown algorithm should fail")
+	}
+}
+
+func TestHashAlgoLabel(t *testing.T) {
+	if got := hashAlgoLabel(""); got != "sha256" {
+		t.Errorf("hashAlgoLabel(\"\") = %q, want %q", got, "sha256")
+	}
+	if got := hashAlgoLabel("sha256"); got != "sha256" {
+		t.Errorf("hashAlgoLabel(\"sha256\") = %q, want %q", got, "sha256")
+	}
+}
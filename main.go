@@ -3,11 +3,14 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"unicode/utf16"
 )
 
@@ -15,6 +18,7 @@ import (
 type FileEntry struct {
 	Path string
 	Size int64
+	Hash string // lower-case hex digest, empty if the listing carried none
 }
 
 // DMDEData holds all parsed data from a DMDE file
@@ -25,26 +29,65 @@ type DMDEData struct {
 	ExpectedFileCount int
 }
 
+// cliOptions holds the flags parsed from argv beyond the positional
+// arguments (mode, dmde_file, and - for every mode but -l/--list -
+// target_directory).
+type cliOptions struct {
+	includes     []string
+	excludes     []string
+	filterFile   string
+	glob         string
+	jobs         int
+	hashAlgo     string
+	emitManifest string
+}
+
 func main() {
-	if len(os.Args) < 4 {
+	if len(os.Args) < 3 {
 		printUsage()
 		os.Exit(1)
 	}
 
 	mode := os.Args[1]
 	dmdeFile := os.Args[2]
-	targetDir := os.Args[3]
 
 	// Validate mode flag
 	isCreate := mode == "-c" || mode == "--create"
-	isVerify := mode == "-v" || mode == "--verify"
+	isVerify := mode == "-v" || mode == "--verify" || mode == "--diff"
+	isList := mode == "-l" || mode == "--list"
 
-	if !isCreate && !isVerify {
-		fmt.Printf("Error: Invalid mode '%s'. Must be -c/--create or -v/--verify\n\n", mode)
+	if !isCreate && !isVerify && !isList {
+		fmt.Printf("Error: Invalid mode '%s'. Must be -c/--create, -v/--verify, --diff or -l/--list\n\n", mode)
 		printUsage()
 		os.Exit(1)
 	}
 
+	var targetDir string
+	var flagArgs []string
+	if isList {
+		flagArgs = os.Args[3:]
+	} else {
+		if len(os.Args) < 4 {
+			printUsage()
+			os.Exit(1)
+		}
+		targetDir = os.Args[3]
+		flagArgs = os.Args[4:]
+	}
+
+	opts, err := parseCLIOptions(flagArgs)
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		printUsage()
+		os.Exit(1)
+	}
+
+	filter, err := buildSelectFilter(opts)
+	if err != nil {
+		fmt.Printf("Error building filter: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Parse the DMDE file
 	data, err := parseDMDEFile(dmdeFile)
 	if err != nil {
@@ -52,34 +95,117 @@ func main() {
 		os.Exit(1)
 	}
 
-	if isCreate {
-		runCreateMode(data, targetDir)
-	} else {
-		runVerifyMode(data, targetDir)
+	switch {
+	case isCreate:
+		runCreateMode(data, targetDir, filter, opts.emitManifest, opts.hashAlgo)
+	case isList:
+		runListMode(data, opts.glob)
+	default:
+		runVerifyMode(data, targetDir, filter, opts.glob, opts.jobs, opts.hashAlgo)
+	}
+}
+
+// parseCLIOptions parses the flags that follow the positional arguments:
+// the repeatable --include/--exclude and the single --filter-file, --glob,
+// --jobs, --hash and --emit-manifest (a repeated single flag keeps its
+// last value).
+func parseCLIOptions(args []string) (*cliOptions, error) {
+	opts := &cliOptions{}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--include":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--include requires a glob pattern")
+			}
+			opts.includes = append(opts.includes, args[i])
+		case "--exclude":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--exclude requires a glob pattern")
+			}
+			opts.excludes = append(opts.excludes, args[i])
+		case "--filter-file":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--filter-file requires a path")
+			}
+			opts.filterFile = args[i]
+		case "--glob":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--glob requires a pattern")
+			}
+			opts.glob = args[i]
+		case "--jobs":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--jobs requires a worker count")
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("--jobs requires a positive integer, got %q", args[i])
+			}
+			opts.jobs = n
+		case "--hash":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--hash requires an algorithm name")
+			}
+			opts.hashAlgo = args[i]
+		case "--emit-manifest":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--emit-manifest requires a path")
+			}
+			opts.emitManifest = args[i]
+		default:
+			return nil, fmt.Errorf("unknown flag %q", args[i])
+		}
 	}
+	return opts, nil
 }
 
 func printUsage() {
-	fmt.Println("Usage: dirparser <mode> <dmde_file> <target_directory>")
+	fmt.Println("Usage: dirparser <mode> <dmde_file> [target_directory] [filters]")
 	fmt.Println()
 	fmt.Println("Modes:")
 	fmt.Println("  -c, --create    Create directory structure from DMDE file listing")
 	fmt.Println("  -v, --verify    Verify recovered files against DMDE file listing")
+	fmt.Println("  --diff          Alias for --verify, typically paired with --glob to scope the diff")
+	fmt.Println("  -l, --list      List DMDE listing entries without touching the filesystem")
 	fmt.Println()
 	fmt.Println("Arguments:")
 	fmt.Println("  <dmde_file>         Path to the DMDE file listing")
-	fmt.Println("  <target_directory>  Directory to create structure in / verify against")
+	fmt.Println("  [target_directory]  Directory to create structure in / verify against (not used by -l/--list)")
+	fmt.Println()
+	fmt.Println("Filters and options (patterns match the DMDE-relative path; only --include/--exclude are repeatable):")
+	fmt.Println("  --include <glob>       Only process entries matching this glob")
+	fmt.Println("  --exclude <glob>       Skip entries matching this glob")
+	fmt.Println("  --filter-file <path>   Gitignore-style filter file ('!' negates, '#include other' pulls in more rules)")
+	fmt.Println("  --glob <pattern>       Scope -l/--list or -v/--diff to entries matching this glob ('**' matches any number of path segments)")
+	fmt.Println("  --jobs <N>             Number of concurrent workers for -v/--verify/--diff (default: number of CPUs)")
+	fmt.Println("  --hash <algo>          Hash algorithm for content checks and --emit-manifest (default, and only one available: sha256)")
+	fmt.Println("  --emit-manifest <path> (-c/--create) Write a path/size/hash sidecar for files already present under target_directory")
+	fmt.Println()
+	fmt.Println("Content verification:")
+	fmt.Println("  If <dmde_file>.sha256 exists (sha256sum format, keyed by DMDE-relative path), -v/--diff")
+	fmt.Println("  streams matching files through the hash and reports HASH MISMATCH alongside size checks.")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  dirparser -c filelist.txt ./recovered")
 	fmt.Println("  dirparser --verify filelist.txt ./recovered")
+	fmt.Println("  dirparser -v filelist.txt ./recovered --exclude 'Users/*/AppData/**'")
+	fmt.Println("  dirparser -l filelist.txt --glob '**/*.psd'")
+	fmt.Println("  dirparser --diff filelist.txt ./recovered --glob 'Users/**'")
+	fmt.Println("  dirparser -c filelist.txt ./recovered --emit-manifest filelist.txt.sha256")
 }
 
-func runCreateMode(data *DMDEData, outputDir string) {
+func runCreateMode(data *DMDEData, outputDir string, filter *SelectFilter, manifestPath string, hashAlgo string) {
 	fmt.Printf("Found %d directories in the DMDE file\n", len(data.Directories))
 
 	// Create the directories
-	createdCount, skippedCount, err := createDirectories(data.Directories, outputDir)
+	createdCount, skippedCount, err := createDirectories(data.Directories, outputDir, filter)
 	if err != nil {
 		fmt.Printf("Error creating directories: %v\n", err)
 		os.Exit(1)
@@ -87,183 +213,249 @@ func runCreateMode(data *DMDEData, outputDir string) {
 
 	fmt.Printf("\nSummary: %d created, %d already existed\n", createdCount, skippedCount)
 
-	// Verify the count
-	totalProcessed := createdCount + skippedCount
-	if data.ExpectedDirCount > 0 {
-		if totalProcessed == data.ExpectedDirCount {
-			fmt.Printf("✓ Verification successful: Processed %d directories (matches expected count)\n", totalProcessed)
+	if filter.Active() {
+		fmt.Println("Note: filters are active, skipping directory count verification")
+	} else {
+		// Verify the count
+		totalProcessed := createdCount + skippedCount
+		if data.ExpectedDirCount > 0 {
+			if totalProcessed == data.ExpectedDirCount {
+				fmt.Printf("✓ Verification successful: Processed %d directories (matches expected count)\n", totalProcessed)
+			} else {
+				fmt.Printf("✗ Verification failed: Processed %d directories, but expected %d\n", totalProcessed, data.ExpectedDirCount)
+				os.Exit(1)
+			}
 		} else {
-			fmt.Printf("✗ Verification failed: Processed %d directories, but expected %d\n", totalProcessed, data.ExpectedDirCount)
-			os.Exit(1)
+			fmt.Println("Warning: Could not find 'Total directories:' in the file for verification")
 		}
-	} else {
-		fmt.Println("Warning: Could not find 'Total directories:' in the file for verification")
 	}
+
+	if manifestPath == "" {
+		return
+	}
+	fmt.Printf("\nHashing files under %s for manifest...\n", outputDir)
+	count, err := emitManifest(outputDir, manifestPath, hashAlgo)
+	if err != nil {
+		fmt.Printf("Error writing manifest: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d entries to %s\n", count, manifestPath)
 }
 
-func runVerifyMode(data *DMDEData, targetDir string) {
-	fmt.Printf("Verifying against DMDE listing: %d directories, %d files\n", len(data.Directories), len(data.Files))
-	fmt.Println()
+func runVerifyMode(data *DMDEData, targetDir string, filter *SelectFilter, glob string, jobs int, hashAlgo string) {
+	expectedFS, err := NewDMDEFS(data)
+	if err != nil {
+		fmt.Printf("Error building virtual file tree: %v\n", err)
+		os.Exit(1)
+	}
+	entries, err := collectDMDETree(expectedFS)
+	if err != nil {
+		fmt.Printf("Error walking virtual file tree: %v\n", err)
+		os.Exit(1)
+	}
 
-	hasErrors := false
-	hasWarnings := false
+	jobCount := resolveJobCount(jobs)
 
-	// Track all expected paths (for detecting extra files)
-	expectedPaths := make(map[string]bool)
-	for _, dir := range data.Directories {
-		normalizedPath := filepath.FromSlash(strings.ReplaceAll(dir, "\\", "/"))
-		fullPath := filepath.Join(targetDir, normalizedPath)
-		expectedPaths[strings.ToLower(fullPath)] = true
+	fmt.Printf("Verifying against DMDE listing: %d directories, %d files\n", len(data.Directories), len(data.Files))
+	fmt.Printf("Using %d worker(s)\n", jobCount)
+	if filter.Active() {
+		fmt.Println("Filters active: excluded and not-included entries are skipped entirely")
 	}
-	for _, file := range data.Files {
-		normalizedPath := filepath.FromSlash(strings.ReplaceAll(file.Path, "\\", "/"))
-		fullPath := filepath.Join(targetDir, normalizedPath)
-		expectedPaths[strings.ToLower(fullPath)] = true
+	if glob != "" {
+		fmt.Printf("Scoped to entries matching glob %q\n", glob)
 	}
+	fmt.Println()
 
-	// Verify directories
-	fmt.Println("=== Checking Directories ===")
-	missingDirs := 0
-	existingDirs := 0
-	for _, dir := range data.Directories {
-		normalizedPath := filepath.FromSlash(strings.ReplaceAll(dir, "\\", "/"))
-		fullPath := filepath.Join(targetDir, normalizedPath)
-
-		if info, err := os.Stat(fullPath); err != nil {
-			fmt.Printf("  ✗ MISSING DIR:  %s\n", fullPath)
-			missingDirs++
-			hasErrors = true
-		} else if !info.IsDir() {
-			fmt.Printf("  ✗ NOT A DIR:    %s (exists as file)\n", fullPath)
-			missingDirs++
-			hasErrors = true
-		} else {
-			existingDirs++
+	actualFS := os.DirFS(targetDir)
+
+	// A filter excludes entries from every check, not just "missing": an
+	// excluded listing entry shouldn't be reported missing, and a
+	// matching real file shouldn't be reported extra either. A glob
+	// scopes the diff the same way.
+	if filter.Active() || glob != "" {
+		filtered := make([]dmdeEntry, 0, len(entries))
+		for _, e := range entries {
+			if !filter.Allows(e.path, e.isDir) {
+				continue
+			}
+			if glob != "" && !matchGlob(glob, e.path) {
+				continue
+			}
+			filtered = append(filtered, e)
 		}
+		entries = filtered
 	}
-	fmt.Printf("  Directories: %d OK, %d missing\n", existingDirs, missingDirs)
-
-	// Verify files
-	fmt.Println()
-	fmt.Println("=== Checking Files ===")
-	missingFiles := 0
-	okFiles := 0
-	sizeMismatch := 0
-	for _, file := range data.Files {
-		normalizedPath := filepath.FromSlash(strings.ReplaceAll(file.Path, "\\", "/"))
-		fullPath := filepath.Join(targetDir, normalizedPath)
 
-		info, err := os.Stat(fullPath)
-		if err != nil {
-			fmt.Printf("  ✗ MISSING FILE: %s\n", fullPath)
-			missingFiles++
-			hasErrors = true
-		} else if info.IsDir() {
-			fmt.Printf("  ✗ NOT A FILE:   %s (exists as directory)\n", fullPath)
-			missingFiles++
-			hasErrors = true
+	var dirEntries, fileEntries []dmdeEntry
+	expectedPaths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		expectedPaths = append(expectedPaths, strings.ToLower(e.path))
+		if e.isDir {
+			dirEntries = append(dirEntries, e)
 		} else {
-			// Check file size
-			if file.Size >= 0 && info.Size() != file.Size {
-				fmt.Printf("  ⚠ SIZE MISMATCH: %s (expected %d bytes, got %d bytes)\n", fullPath, file.Size, info.Size())
-				sizeMismatch++
-				hasErrors = true
-			} else {
-				okFiles++
-			}
+			fileEntries = append(fileEntries, e)
 		}
 	}
-	fmt.Printf("  Files: %d OK, %d missing, %d wrong size\n", okFiles, missingFiles, sizeMismatch)
+	expected := newSortedPathSet(expectedPaths)
 
-	// Check for extra files not in the listing
-	// Only check within the root directories from the DMDE listing
-	fmt.Println()
-	fmt.Println("=== Checking for Extra Files ===")
-	extraFiles := 0
-	extraDirs := 0
-
-	// Find root directories from the listing (top-level dirs that contain all others)
-	rootDirs := findRootDirs(data.Directories, targetDir)
-	if len(rootDirs) == 0 {
-		fmt.Println("  No root directories found in listing, skipping extra files check")
-	} else {
-		fmt.Println("  Scanning within:")
-		for _, rd := range rootDirs {
-			fmt.Printf("    %s\n", rd)
+	hashedFiles := 0
+	for _, e := range fileEntries {
+		if e.hash != "" {
+			hashedFiles++
 		}
-		for _, rootDir := range rootDirs {
-			err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return nil // Skip files we can't access
-				}
-				if path == rootDir {
-					return nil // Skip the root directory itself
-				}
+	}
+	if hashedFiles > 0 {
+		if _, err := newHasher(hashAlgo); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Content hashes available for %d file(s), verifying with %s\n\n", hashedFiles, hashAlgoLabel(hashAlgo))
+	}
 
-				lowerPath := strings.ToLower(path)
-				if !expectedPaths[lowerPath] {
-					if info.IsDir() {
-						fmt.Printf("  ⚠ EXTRA DIR:  %s\n", path)
-						extraDirs++
-					} else {
-						fmt.Printf("  ⚠ EXTRA FILE: %s\n", path)
-						extraFiles++
-					}
-					hasWarnings = true
-				}
-				return nil
-			})
-			if err != nil {
-				fmt.Printf("  Error walking directory: %v\n", err)
+	counters := &verifyCounters{}
+	out, printerDone := startPrinter()
+
+	// Verify directories
+	out <- "=== Checking Directories ==="
+	checkDirectoriesConcurrently(actualFS, targetDir, dirEntries, jobCount, counters, out)
+	out <- fmt.Sprintf("  Directories: %d OK, %d missing", counters.existingDirs, counters.missingDirs)
+
+	// Verify files
+	out <- ""
+	out <- "=== Checking Files ==="
+	checkFilesConcurrently(actualFS, targetDir, fileEntries, jobCount, hashAlgo, counters, out)
+	out <- fmt.Sprintf("  Files: %d OK, %d missing, %d wrong size, %d hash mismatch", counters.okFiles, counters.missingFiles, counters.sizeMismatch, counters.hashMismatch)
+
+	// Check for extra files not in the listing
+	// Only check within the root directories of the virtual DMDE tree
+	out <- ""
+	out <- "=== Checking for Extra Files ==="
+
+	rootEntries, rootErr := fs.ReadDir(expectedFS, ".")
+	if rootErr != nil || len(rootEntries) == 0 {
+		out <- "  No root directories found in listing, skipping extra files check"
+	} else {
+		out <- "  Scanning within:"
+		rootNames := make([]string, 0, len(rootEntries))
+		for _, re := range rootEntries {
+			// A glob that can't possibly match anything under this root
+			// (e.g. glob "Users/**" against root "Program Files") rules
+			// the whole root out up front, without walking it at all.
+			if glob != "" && !globMayMatchPrefix(glob, re.Name()) {
+				continue
 			}
+			rootNames = append(rootNames, re.Name())
+			out <- fmt.Sprintf("    %s", filepath.Join(targetDir, re.Name()))
 		}
+		scanExtrasConcurrently(actualFS, targetDir, rootNames, expected, filter, glob, jobCount, counters, out)
+		runFSConformanceCheck(expectedFS, rootNames, out)
 	}
-	if extraFiles == 0 && extraDirs == 0 {
-		fmt.Println("  No extra files or directories found")
+	if counters.extraFiles == 0 && counters.extraDirs == 0 {
+		out <- "  No extra files or directories found"
 	} else {
-		fmt.Printf("  Found %d extra files, %d extra directories\n", extraFiles, extraDirs)
+		out <- fmt.Sprintf("  Found %d extra files, %d extra directories", counters.extraFiles, counters.extraDirs)
 	}
 
 	// Summary
-	fmt.Println()
-	fmt.Println("=== Summary ===")
-	if missingDirs == 0 {
-		fmt.Printf("  Directories: %d/%d ✓\n", existingDirs, len(data.Directories))
+	out <- ""
+	out <- "=== Summary ==="
+	if counters.missingDirs == 0 {
+		out <- fmt.Sprintf("  Directories: %d/%d ✓", counters.existingDirs, len(data.Directories))
 	} else {
-		fmt.Printf("  Directories: %d/%d (%d missing)\n", existingDirs, len(data.Directories), missingDirs)
+		out <- fmt.Sprintf("  Directories: %d/%d (%d missing)", counters.existingDirs, len(data.Directories), counters.missingDirs)
 	}
-	if missingFiles == 0 && sizeMismatch == 0 {
-		fmt.Printf("  Files:       %d/%d ✓\n", okFiles, len(data.Files))
+	if counters.missingFiles == 0 && counters.sizeMismatch == 0 && counters.hashMismatch == 0 {
+		out <- fmt.Sprintf("  Files:       %d/%d ✓", counters.okFiles, len(data.Files))
 	} else {
 		issues := []string{}
-		if missingFiles > 0 {
-			issues = append(issues, fmt.Sprintf("%d missing", missingFiles))
+		if counters.missingFiles > 0 {
+			issues = append(issues, fmt.Sprintf("%d missing", counters.missingFiles))
+		}
+		if counters.sizeMismatch > 0 {
+			issues = append(issues, fmt.Sprintf("%d wrong size", counters.sizeMismatch))
 		}
-		if sizeMismatch > 0 {
-			issues = append(issues, fmt.Sprintf("%d wrong size", sizeMismatch))
+		if counters.hashMismatch > 0 {
+			issues = append(issues, fmt.Sprintf("%d hash mismatch", counters.hashMismatch))
 		}
-		fmt.Printf("  Files:       %d/%d (%s)\n", okFiles, len(data.Files), strings.Join(issues, ", "))
+		out <- fmt.Sprintf("  Files:       %d/%d (%s)", counters.okFiles, len(data.Files), strings.Join(issues, ", "))
 	}
-	if extraFiles > 0 || extraDirs > 0 {
-		fmt.Printf("  Extra items: %d files, %d directories\n", extraFiles, extraDirs)
+	if counters.extraFiles > 0 || counters.extraDirs > 0 {
+		out <- fmt.Sprintf("  Extra items: %d files, %d directories", counters.extraFiles, counters.extraDirs)
 	}
 
 	// Note about parsed vs expected counts (only show if there's a discrepancy in parsing)
-	fmt.Println()
+	out <- ""
 	if data.ExpectedDirCount > 0 && len(data.Directories) != data.ExpectedDirCount {
-		fmt.Printf("⚠ Warning: Parsed %d directories from listing, but file claims %d\n", len(data.Directories), data.ExpectedDirCount)
+		out <- fmt.Sprintf("⚠ Warning: Parsed %d directories from listing, but file claims %d", len(data.Directories), data.ExpectedDirCount)
 	}
 	if data.ExpectedFileCount > 0 && len(data.Files) != data.ExpectedFileCount {
-		fmt.Printf("⚠ Warning: Parsed %d files from listing, but file claims %d\n", len(data.Files), data.ExpectedFileCount)
+		out <- fmt.Sprintf("⚠ Warning: Parsed %d files from listing, but file claims %d", len(data.Files), data.ExpectedFileCount)
 	}
 
+	hasErrors := atomic.LoadInt32(&counters.hasErrors) != 0
+	hasWarnings := atomic.LoadInt32(&counters.hasWarnings) != 0
 	if hasErrors {
-		fmt.Println("✗ Verification completed with errors")
-		os.Exit(1)
+		out <- "✗ Verification completed with errors"
 	} else if hasWarnings {
-		fmt.Println("⚠ Verification completed with warnings")
+		out <- "⚠ Verification completed with warnings"
+	} else {
+		out <- "✓ Verification successful - all files and directories match"
+	}
+
+	close(out)
+	<-printerDone
+
+	if hasErrors {
+		os.Exit(1)
+	}
+}
+
+// runListMode prints the DMDE listing entries matching glob (or every
+// entry, if glob is empty) without touching the filesystem at all.
+func runListMode(data *DMDEData, glob string) {
+	type listEntry struct {
+		path  string
+		isDir bool
+		size  int64
+	}
+
+	var entries []listEntry
+	for _, dir := range data.Directories {
+		rel, err := dmdeRelPath(dir)
+		if err != nil {
+			continue
+		}
+		if glob != "" && !matchGlob(glob, rel) {
+			continue
+		}
+		entries = append(entries, listEntry{path: rel, isDir: true})
+	}
+	for _, file := range data.Files {
+		rel, err := dmdeRelPath(file.Path)
+		if err != nil {
+			continue
+		}
+		if glob != "" && !matchGlob(glob, rel) {
+			continue
+		}
+		entries = append(entries, listEntry{path: rel, size: file.Size})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+
+	for _, e := range entries {
+		if e.isDir {
+			fmt.Printf("  %12s  %s/\n", "<DIR>", e.path)
+		} else {
+			fmt.Printf("  %12d  %s\n", e.size, e.path)
+		}
+	}
+
+	fmt.Println()
+	if glob != "" {
+		fmt.Printf("%d entries matching %q\n", len(entries), glob)
 	} else {
-		fmt.Println("✓ Verification successful - all files and directories match")
+		fmt.Printf("%d entries\n", len(entries))
 	}
 }
 
@@ -331,6 +523,9 @@ func parseDMDEFile(filePath string) (*DMDEData, error) {
 	// Format: date time  size  flags  flags  [category]  path
 	// Note: category may be empty, so we only require 2 flag fields before the path
 	fileRegex := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2}:\d{2}\.\d+\s+(\d+)\s+\S+\s+\S+\s+(.+?)\s*$`)
+	// Optional inline hash column, for DMDE listings configured to export
+	// one: "... path  Hash:<hex>". Stripped from the path before storing it.
+	hashColumnRegex := regexp.MustCompile(`^(.*?)\s+Hash:([0-9a-fA-F]{32,128})$`)
 	// Regex to extract the total directories count
 	totalDirsRegex := regexp.MustCompile(`Total directories:\s*(\d+)`)
 	// Regex to extract the total files count
@@ -359,9 +554,15 @@ func parseDMDEFile(filePath string) (*DMDEData, error) {
 				size, _ := strconv.ParseInt(matches[1], 10, 64)
 				// Strip optional category prefix using known categories
 				filePath := stripCategoryFromPath(matches[2], knownCategories)
+				var hash string
+				if hashMatches := hashColumnRegex.FindStringSubmatch(filePath); len(hashMatches) > 2 {
+					filePath = hashMatches[1]
+					hash = strings.ToLower(hashMatches[2])
+				}
 				data.Files = append(data.Files, FileEntry{
 					Path: filePath,
 					Size: size,
+					Hash: hash,
 				})
 			}
 			continue
@@ -394,6 +595,25 @@ func parseDMDEFile(filePath string) (*DMDEData, error) {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
+	// A companion "<listing>.sha256" sidecar can supply hashes for files
+	// the listing itself didn't carry an inline Hash: column for.
+	sidecarHashes, err := loadHashSidecar(filePath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range data.Files {
+		if data.Files[i].Hash != "" {
+			continue
+		}
+		rel, err := dmdeRelPath(data.Files[i].Path)
+		if err != nil {
+			continue
+		}
+		if h, ok := sidecarHashes[strings.ToLower(rel)]; ok {
+			data.Files[i].Hash = h
+		}
+	}
+
 	return data, nil
 }
 
@@ -447,15 +667,25 @@ func decodeUTF16BE(data []byte) string {
 	return string(utf16.Decode(u16s))
 }
 
-// createDirectories creates all directories in the specified output location
+// createDirectories creates all directories in the specified output location.
+// Entries that filter excludes (or that don't match an active --include) are
+// skipped entirely - they count as neither created nor skipped.
 // Returns (created count, skipped count, error)
-func createDirectories(directories []string, outputDir string) (int, int, error) {
+func createDirectories(directories []string, outputDir string, filter *SelectFilter) (int, int, error) {
 	createdCount := 0
 	skippedCount := 0
 
 	for _, dir := range directories {
+		rel, err := dmdeRelPath(dir)
+		if err != nil {
+			return createdCount, skippedCount, err
+		}
+		if !filter.Allows(rel, true) {
+			continue
+		}
+
 		// Convert Windows-style path separators to OS-specific ones
-		dir = filepath.FromSlash(strings.ReplaceAll(dir, "\\", "/"))
+		dir = filepath.FromSlash(rel)
 
 		fullPath := filepath.Join(outputDir, dir)
 
@@ -467,7 +697,7 @@ func createDirectories(directories []string, outputDir string) (int, int, error)
 		}
 
 		// Create the directory (and any parent directories)
-		err := os.MkdirAll(fullPath, 0755)
+		err = os.MkdirAll(fullPath, 0755)
 		if err != nil {
 			return createdCount, skippedCount, fmt.Errorf("failed to create directory '%s': %w", fullPath, err)
 		}
@@ -477,45 +707,3 @@ func createDirectories(directories []string, outputDir string) (int, int, error)
 
 	return createdCount, skippedCount, nil
 }
-
-// findRootDirs finds the top-level directories from the DMDE listing
-// These are directories that are not subdirectories of any other directory in the listing
-func findRootDirs(directories []string, targetDir string) []string {
-	if len(directories) == 0 {
-		return nil
-	}
-
-	// Normalize all directory paths
-	normalized := make([]string, len(directories))
-	for i, dir := range directories {
-		normalized[i] = strings.ToLower(filepath.FromSlash(strings.ReplaceAll(dir, "\\", "/")))
-	}
-
-	// Find directories that are not subdirectories of any other
-	var roots []string
-	for _, dir := range normalized {
-		isRoot := true
-		for _, other := range normalized {
-			if dir != other && strings.HasPrefix(dir, other+string(filepath.Separator)) {
-				isRoot = false
-				break
-			}
-		}
-		if isRoot {
-			// Only add if not already in roots (avoid duplicates)
-			fullPath := filepath.Join(targetDir, dir)
-			alreadyAdded := false
-			for _, r := range roots {
-				if strings.EqualFold(r, fullPath) {
-					alreadyAdded = true
-					break
-				}
-			}
-			if !alreadyAdded {
-				roots = append(roots, fullPath)
-			}
-		}
-	}
-
-	return roots
-}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// resolveJobCount turns the user-supplied --jobs value into a worker
+// count: 0 or negative means "default to one worker per CPU".
+func resolveJobCount(jobs int) int {
+	if jobs <= 0 {
+		return runtime.NumCPU()
+	}
+	return jobs
+}
+
+// verifyCounters accumulates the verify summary across concurrent
+// workers. Workers update every field through sync/atomic, and the
+// int64 counts are read directly only after the pool that updates them
+// has returned, so the final counts are deterministic no matter which
+// worker finishes first.
+type verifyCounters struct {
+	existingDirs int64
+	missingDirs  int64
+	okFiles      int64
+	missingFiles int64
+	sizeMismatch int64
+	hashMismatch int64
+	extraFiles   int64
+	extraDirs    int64
+	hasErrors    int32
+	hasWarnings  int32
+}
+
+func (c *verifyCounters) markError()   { atomic.StoreInt32(&c.hasErrors, 1) }
+func (c *verifyCounters) markWarning() { atomic.StoreInt32(&c.hasWarnings, 1) }
+
+// sortedPathSet is a read-only, pre-sorted set of lower-cased relative
+// paths queried by binary search, so concurrent extras-scan workers can
+// look paths up without any lock contention.
+type sortedPathSet struct {
+	sorted []string
+}
+
+func newSortedPathSet(paths []string) *sortedPathSet {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	return &sortedPathSet{sorted: sorted}
+}
+
+func (s *sortedPathSet) Contains(p string) bool {
+	i := sort.SearchStrings(s.sorted, p)
+	return i < len(s.sorted) && s.sorted[i] == p
+}
+
+// printer drains messages from a single channel and writes them to
+// stdout one at a time, so concurrent workers can never interleave a
+// half-written line. Callers enqueue in the order they want things to
+// appear; since a channel never reorders what's already been enqueued,
+// sending a section's summary line only after that section's worker
+// pool has fully drained is enough to keep output readable.
+func startPrinter() (out chan<- string, done <-chan struct{}) {
+	lines := make(chan string, 64)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for line := range lines {
+			fmt.Println(line)
+		}
+	}()
+	return lines, finished
+}
+
+// checkDirectoriesConcurrently stats every directory entry across
+// jobCount workers and reports through out.
+func checkDirectoriesConcurrently(actualFS fs.FS, targetDir string, entries []dmdeEntry, jobCount int, counters *verifyCounters, out chan<- string) {
+	runWorkerPool(entries, jobCount, func(e dmdeEntry) {
+		fullPath := filepath.Join(targetDir, filepath.FromSlash(e.path))
+		info, err := fs.Stat(actualFS, e.path)
+		switch {
+		case err != nil:
+			atomic.AddInt64(&counters.missingDirs, 1)
+			counters.markError()
+			out <- fmt.Sprintf("  ✗ MISSING DIR:  %s", fullPath)
+		case !info.IsDir():
+			atomic.AddInt64(&counters.missingDirs, 1)
+			counters.markError()
+			out <- fmt.Sprintf("  ✗ NOT A DIR:    %s (exists as file)", fullPath)
+		default:
+			atomic.AddInt64(&counters.existingDirs, 1)
+		}
+	})
+}
+
+// checkFilesConcurrently stats every file entry across jobCount workers,
+// comparing size and, when the listing carried a hash for that entry,
+// streaming its content through hashAlgo to check for bit-rot too.
+func checkFilesConcurrently(actualFS fs.FS, targetDir string, entries []dmdeEntry, jobCount int, hashAlgo string, counters *verifyCounters, out chan<- string) {
+	runWorkerPool(entries, jobCount, func(e dmdeEntry) {
+		fullPath := filepath.Join(targetDir, filepath.FromSlash(e.path))
+		info, err := fs.Stat(actualFS, e.path)
+		switch {
+		case err != nil:
+			atomic.AddInt64(&counters.missingFiles, 1)
+			counters.markError()
+			out <- fmt.Sprintf("  ✗ MISSING FILE: %s", fullPath)
+			return
+		case info.IsDir():
+			atomic.AddInt64(&counters.missingFiles, 1)
+			counters.markError()
+			out <- fmt.Sprintf("  ✗ NOT A FILE:   %s (exists as directory)", fullPath)
+			return
+		case e.size >= 0 && info.Size() != e.size:
+			atomic.AddInt64(&counters.sizeMismatch, 1)
+			counters.markError()
+			out <- fmt.Sprintf("  ⚠ SIZE MISMATCH: %s (expected %d bytes, got %d bytes)", fullPath, e.size, info.Size())
+			return
+		}
+		if e.hash == "" {
+			atomic.AddInt64(&counters.okFiles, 1)
+			return
+		}
+		actual, err := hashFile(actualFS, e.path, hashAlgo)
+		switch {
+		case err != nil:
+			atomic.AddInt64(&counters.hashMismatch, 1)
+			counters.markError()
+			out <- fmt.Sprintf("  ✗ HASH MISMATCH: %s (could not hash: %v)", fullPath, err)
+		case actual != e.hash:
+			atomic.AddInt64(&counters.hashMismatch, 1)
+			counters.markError()
+			out <- fmt.Sprintf("  ✗ HASH MISMATCH: %s (expected %s, got %s)", fullPath, e.hash, actual)
+		default:
+			atomic.AddInt64(&counters.okFiles, 1)
+		}
+	})
+}
+
+// runWorkerPool fans entries out across jobCount goroutines, each
+// calling process for every entry it's handed, and blocks until all of
+// them are done.
+func runWorkerPool(entries []dmdeEntry, jobCount int, process func(e dmdeEntry)) {
+	if jobCount < 1 {
+		jobCount = 1
+	}
+	jobs := make(chan dmdeEntry)
+	var wg sync.WaitGroup
+	wg.Add(jobCount)
+	for w := 0; w < jobCount; w++ {
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				process(e)
+			}
+		}()
+	}
+	for _, e := range entries {
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// scanExtrasConcurrently walks each root of the recovered tree in its
+// own goroutine (bounded to jobCount concurrent roots), flagging any
+// path that isn't in expected as extra. filter (via shouldPruneExtra)
+// and glob (via globMayMatchPrefix) prune the walk; each root is walked
+// by a single goroutine.
+func scanExtrasConcurrently(actualFS fs.FS, targetDir string, rootNames []string, expected *sortedPathSet, filter *SelectFilter, glob string, jobCount int, counters *verifyCounters, out chan<- string) {
+	if jobCount < 1 {
+		jobCount = 1
+	}
+	roots := make(chan string)
+	var wg sync.WaitGroup
+	wg.Add(jobCount)
+	for w := 0; w < jobCount; w++ {
+		go func() {
+			defer wg.Done()
+			for rootName := range roots {
+				err := fs.WalkDir(actualFS, rootName, func(p string, d fs.DirEntry, err error) error {
+					if err != nil {
+						return nil // Skip files we can't access
+					}
+					if p == rootName {
+						return nil // Skip the root directory itself
+					}
+					if shouldPruneExtra(filter, p, d.IsDir()) {
+						if d.IsDir() {
+							return fs.SkipDir
+						}
+						return nil
+					}
+					if glob != "" && !globMayMatchPrefix(glob, p) {
+						if d.IsDir() {
+							return fs.SkipDir
+						}
+						return nil
+					}
+					if glob != "" && !matchGlob(glob, p) {
+						return nil // glob may still match a descendant
+					}
+
+					if !expected.Contains(strings.ToLower(p)) {
+						fullPath := filepath.Join(targetDir, filepath.FromSlash(p))
+						if d.IsDir() {
+							atomic.AddInt64(&counters.extraDirs, 1)
+							out <- fmt.Sprintf("  ⚠ EXTRA DIR:  %s", fullPath)
+						} else {
+							atomic.AddInt64(&counters.extraFiles, 1)
+							out <- fmt.Sprintf("  ⚠ EXTRA FILE: %s", fullPath)
+						}
+						counters.markWarning()
+					}
+					return nil
+				})
+				if err != nil {
+					out <- fmt.Sprintf("  Error walking directory: %v", err)
+				}
+			}
+		}()
+	}
+	for _, rootName := range rootNames {
+		roots <- rootName
+	}
+	close(roots)
+	wg.Wait()
+}
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestResolveJobCount(t *testing.T) {
+	if got := resolveJobCount(4); got != 4 {
+		t.Errorf("resolveJobCount(4) = %d, want 4", got)
+	}
+	if got := resolveJobCount(0); got < 1 {
+		t.Errorf("resolveJobCount(0) = %d, want at least 1 (NumCPU default)", got)
+	}
+	if got := resolveJobCount(-1); got < 1 {
+		t.Errorf("resolveJobCount(-1) = %d, want at least 1 (NumCPU default)", got)
+	}
+}
+
+func TestSortedPathSetContains(t *testing.T) {
+	set := newSortedPathSet([]string{"users/bob/a.txt", "users/bob/b.txt"})
+
+	if !set.Contains("users/bob/a.txt") {
+		t.Error("expected a.txt to be found")
+	}
+	if set.Contains("users/bob/c.txt") {
+		t.Error("did not expect c.txt to be found")
+	}
+	if set.Contains("") {
+		t.Error("did not expect the empty path to be found")
+	}
+}
+
+func TestRunWorkerPoolProcessesEveryEntry(t *testing.T) {
+	entries := []dmdeEntry{{path: "a"}, {path: "b"}, {path: "c"}}
+	seen := make(chan string, len(entries))
+
+	runWorkerPool(entries, 2, func(e dmdeEntry) {
+		seen <- e.path
+	})
+	close(seen)
+
+	count := 0
+	for range seen {
+		count++
+	}
+	if count != len(entries) {
+		t.Errorf("runWorkerPool processed %d entries, want %d", count, len(entries))
+	}
+}